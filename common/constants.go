@@ -125,6 +125,12 @@ var BatchUpdateInterval int
 
 var RelayTimeout int // unit is second
 
+var RelayConnectTimeout int // unit is second
+
+var RelayResponseHeaderTimeout int // unit is second
+
+var ShutdownTimeout int // unit is second
+
 var RelayMaxIdleConns int
 var RelayMaxIdleConnsPerHost int
 
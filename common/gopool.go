@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/bytedance/gopkg/util/gopool"
 )
@@ -23,3 +24,59 @@ func init() {
 func RelayCtxGo(ctx context.Context, f func()) {
 	relayGoPool.CtxGo(ctx, f)
 }
+
+// billingTasks 跟踪异步执行的计费任务（退款、额度缓存更新），优雅退出时需等待其完成
+var billingTasks = struct {
+	sync.Mutex
+	pending  int
+	draining bool
+	idle     chan struct{}
+}{}
+
+// BillingGo 异步执行计费相关任务，并在 WaitBillingTasks 中等待其完成；
+// 开始退出等待后提交的任务（如后台任务触发的退款）改为同步执行
+func BillingGo(f func()) {
+	billingTasks.Lock()
+	if billingTasks.draining {
+		billingTasks.Unlock()
+		f()
+		return
+	}
+	billingTasks.pending++
+	billingTasks.Unlock()
+	gopool.Go(func() {
+		defer billingTaskDone()
+		f()
+	})
+}
+
+func billingTaskDone() {
+	billingTasks.Lock()
+	defer billingTasks.Unlock()
+	billingTasks.pending--
+	if billingTasks.pending == 0 && billingTasks.idle != nil {
+		close(billingTasks.idle)
+		billingTasks.idle = nil
+	}
+}
+
+// WaitBillingTasks 停止异步提交计费任务，并等待进行中的任务完成，ctx 结束前未完成返回 false
+func WaitBillingTasks(ctx context.Context) bool {
+	billingTasks.Lock()
+	billingTasks.draining = true
+	if billingTasks.pending == 0 {
+		billingTasks.Unlock()
+		return true
+	}
+	if billingTasks.idle == nil {
+		billingTasks.idle = make(chan struct{})
+	}
+	idle := billingTasks.idle
+	billingTasks.Unlock()
+	select {
+	case <-idle:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitBillingTasks(t *testing.T) {
+	billingTasks.Lock()
+	billingTasks.draining = false
+	billingTasks.Unlock()
+
+	var finished atomic.Int32
+	release := make(chan struct{})
+	BillingGo(func() {
+		<-release
+		finished.Add(1)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.False(t, WaitBillingTasks(ctx))
+
+	close(release)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	assert.True(t, WaitBillingTasks(ctx2))
+	assert.Equal(t, int32(1), finished.Load())
+
+	// 开始等待后提交的任务同步执行
+	BillingGo(func() { finished.Add(1) })
+	assert.Equal(t, int32(2), finished.Load())
+}
@@ -40,6 +40,12 @@ func New(ctx context.Context, r *redis.Client) *RedisLimiter {
 }
 
 func (rl *RedisLimiter) Allow(ctx context.Context, key string, opts ...Option) (bool, error) {
+	allowed, _, err := rl.AllowWithTokens(ctx, key, opts...)
+	return allowed, err
+}
+
+// AllowWithTokens 执行限流判断，同时返回判断后桶内剩余的令牌数
+func (rl *RedisLimiter) AllowWithTokens(ctx context.Context, key string, opts ...Option) (bool, int64, error) {
 	// 默认配置
 	config := &Config{
 		Capacity:  10,
@@ -60,12 +66,15 @@ func (rl *RedisLimiter) Allow(ctx context.Context, key string, opts ...Option) (
 		config.Requested,
 		config.Rate,
 		config.Capacity,
-	).Int()
+	).Int64Slice()
 
 	if err != nil {
-		return false, fmt.Errorf("rate limit failed: %w", err)
+		return false, 0, fmt.Errorf("rate limit failed: %w", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("rate limit failed: unexpected result %v", result)
 	}
-	return result == 1, nil
+	return result[0] == 1, result[1], nil
 }
 
 // Config 配置选项模式
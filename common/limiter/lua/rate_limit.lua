@@ -41,4 +41,5 @@ end
 redis.call('HMSET', key, 'tokens', tokens, 'last_time', last_time)
 --redis.call('EXPIRE', key, math.ceil(capacity / rate) + 60) -- 适当延长过期时间
 
-return allowed and 1 or 0
\ No newline at end of file
+-- 返回是否允许及剩余令牌数
+return {allowed and 1 or 0, tokens}
\ No newline at end of file
@@ -45,9 +45,31 @@ func (l *InMemoryRateLimiter) clearExpiredItems() {
 func (l *InMemoryRateLimiter) Request(key string, maxRequestNum int, duration int64) bool {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
+	return l.request(key, maxRequestNum, duration, time.Now().Unix())
+}
+
+// Status returns the remaining request count of key within the window and the
+// seconds until the oldest request in the window expires. It does not record a request.
+func (l *InMemoryRateLimiter) Status(key string, maxRequestNum int, duration int64) (remaining int, reset int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.status(key, maxRequestNum, duration, time.Now().Unix())
+}
+
+// RequestWithStatus records a request like Request and returns the status after the
+// decision under the same lock, so the reported status always matches the decision.
+func (l *InMemoryRateLimiter) RequestWithStatus(key string, maxRequestNum int, duration int64) (allowed bool, remaining int, reset int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := time.Now().Unix()
+	allowed = l.request(key, maxRequestNum, duration, now)
+	remaining, reset = l.status(key, maxRequestNum, duration, now)
+	return allowed, remaining, reset
+}
+
+func (l *InMemoryRateLimiter) request(key string, maxRequestNum int, duration int64, now int64) bool {
 	// [old <-- new]
 	queue, ok := l.store[key]
-	now := time.Now().Unix()
 	if ok {
 		if len(*queue) < maxRequestNum {
 			*queue = append(*queue, now)
@@ -68,3 +90,24 @@ func (l *InMemoryRateLimiter) Request(key string, maxRequestNum int, duration in
 	}
 	return true
 }
+
+func (l *InMemoryRateLimiter) status(key string, maxRequestNum int, duration int64, now int64) (remaining int, reset int64) {
+	queue, ok := l.store[key]
+	if !ok {
+		return maxRequestNum, 0
+	}
+	used := 0
+	for _, t := range *queue {
+		if now-t < duration {
+			if used == 0 {
+				reset = t + duration - now
+			}
+			used++
+		}
+	}
+	remaining = maxRequestNum - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, reset
+}
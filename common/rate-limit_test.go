@@ -0,0 +1,94 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInMemoryRateLimiterStatus(t *testing.T) {
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name          string
+		queue         []int64
+		maxRequestNum int
+		duration      int64
+		wantRemaining int
+		wantReset     int64
+	}{
+		{
+			name:          "unknown key is unused",
+			queue:         nil,
+			maxRequestNum: 5,
+			duration:      60,
+			wantRemaining: 5,
+			wantReset:     0,
+		},
+		{
+			name:          "requests within window are counted",
+			queue:         []int64{now - 20, now - 10, now},
+			maxRequestNum: 5,
+			duration:      60,
+			wantRemaining: 2,
+			wantReset:     40,
+		},
+		{
+			name:          "expired requests are not counted",
+			queue:         []int64{now - 120, now - 90, now - 30},
+			maxRequestNum: 3,
+			duration:      60,
+			wantRemaining: 2,
+			wantReset:     30,
+		},
+		{
+			name:          "full window",
+			queue:         []int64{now - 5, now - 1, now},
+			maxRequestNum: 3,
+			duration:      60,
+			wantRemaining: 0,
+			wantReset:     55,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &InMemoryRateLimiter{store: make(map[string]*[]int64)}
+			if tt.queue != nil {
+				queue := append([]int64(nil), tt.queue...)
+				l.store["key"] = &queue
+			}
+			remaining, reset := l.status("key", tt.maxRequestNum, tt.duration, now)
+			if remaining != tt.wantRemaining || reset != tt.wantReset {
+				t.Errorf("status() = (%d, %d), want (%d, %d)", remaining, reset, tt.wantRemaining, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestInMemoryRateLimiterStatusDoesNotRecord(t *testing.T) {
+	l := &InMemoryRateLimiter{}
+	l.Init(0)
+	for i := 0; i < 3; i++ {
+		l.Request("key", 5, 60)
+	}
+	for i := 0; i < 2; i++ {
+		if remaining, _ := l.Status("key", 5, 60); remaining != 2 {
+			t.Fatalf("Status() remaining = %d, want 2", remaining)
+		}
+	}
+}
+
+func TestInMemoryRateLimiterRequestWithStatus(t *testing.T) {
+	l := &InMemoryRateLimiter{}
+	l.Init(0)
+	for i := 1; i <= 3; i++ {
+		allowed, remaining, _ := l.RequestWithStatus("key", 3, 60)
+		if !allowed || remaining != 3-i {
+			t.Fatalf("request %d: RequestWithStatus() = (%v, %d), want (true, %d)", i, allowed, remaining, 3-i)
+		}
+	}
+	allowed, remaining, reset := l.RequestWithStatus("key", 3, 60)
+	if allowed || remaining != 0 || reset <= 0 || reset > 60 {
+		t.Fatalf("RequestWithStatus() = (%v, %d, %d), want (false, 0, (0, 60])", allowed, remaining, reset)
+	}
+}
@@ -63,6 +63,10 @@ const (
 	// It is not returned to end users, but can be persisted into consume/error logs for debugging.
 	ContextKeyAdminRejectReason ContextKey = "admin_reject_reason"
 
+	// ContextKeyUpstreamRequestId stores the request id returned by the upstream (x-request-id / request-id),
+	// persisted into consume/error logs so gateway and upstream logs can be correlated.
+	ContextKeyUpstreamRequestId ContextKey = "upstream_request_id"
+
 	// ContextKeyLanguage stores the user's language preference for i18n
 	ContextKeyLanguage ContextKey = "language"
 )
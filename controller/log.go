@@ -5,7 +5,9 @@ import (
 	"strconv"
 
 	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/i18n"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -93,6 +95,36 @@ func GetLogByKey(c *gin.Context) {
 	})
 }
 
+func GetTokenUsageLogs(c *gin.Context) {
+	pageInfo := common.GetPageQuery(c)
+	tokenId := c.GetInt("token_id")
+	// 日志包含客户端 IP 与请求内容，已禁用或过期的令牌（如泄露后被停用）不能再读取
+	token, err := model.GetTokenById(tokenId)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if token.Status == common.TokenStatusExpired || (token.ExpiredTime != -1 && token.ExpiredTime < common.GetTimestamp()) {
+		common.ApiErrorI18n(c, i18n.MsgTokenExpired)
+		return
+	}
+	if token.Status != common.TokenStatusEnabled {
+		common.ApiErrorI18n(c, i18n.MsgTokenStatusUnavailable)
+		return
+	}
+	startTimestamp, _ := strconv.ParseInt(c.Query("start_timestamp"), 10, 64)
+	endTimestamp, _ := strconv.ParseInt(c.Query("end_timestamp"), 10, 64)
+	modelName := c.Query("model_name")
+	logs, total, err := model.GetTokenConsumeLogs(tokenId, startTimestamp, endTimestamp, modelName, pageInfo.GetStartIdx(), pageInfo.GetPageSize())
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	pageInfo.SetTotal(int(total))
+	pageInfo.SetItems(logs)
+	common.ApiSuccess(c, pageInfo)
+}
+
 func GetLogsStat(c *gin.Context) {
 	logType, _ := strconv.Atoi(c.Query("type"))
 	startTimestamp, _ := strconv.ParseInt(c.Query("start_timestamp"), 10, 64)
@@ -169,3 +201,24 @@ func DeleteHistoryLogs(c *gin.Context) {
 	})
 	return
 }
+
+type RefundLogRequest struct {
+	LogId  int    `json:"log_id"`
+	Quota  int    `json:"quota"`
+	Reason string `json:"reason"`
+}
+
+// RefundLog 管理员按消费日志退还额度，quota 为空时退还剩余全部额度
+func RefundLog(c *gin.Context) {
+	var req RefundLogRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.LogId <= 0 || req.Quota < 0 {
+		common.ApiErrorI18n(c, i18n.MsgInvalidParams)
+		return
+	}
+	log, err := service.RefundConsumeLog(c.Request.Context(), req.LogId, req.Quota, req.Reason, c.GetInt("id"), c.GetString("username"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, log)
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+type statementTotal struct {
+	RequestCount     int64   `json:"request_count"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Quota            int     `json:"quota"`
+	Amount           float64 `json:"amount"`
+}
+
+// parseStatementMonth 解析 YYYY-MM 格式的账单月份，为空时取当月
+func parseStatementMonth(month string) (time.Time, time.Time, error) {
+	if month == "" {
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("月份格式错误，应为 YYYY-MM")
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// statementCurrency 返回账单金额使用的币种与符号，与额度展示设置一致；
+// 自定义币种没有币种代码，以配置的货币符号标识；按 token 展示时金额以美元计
+func statementCurrency() (currency string, symbol string) {
+	switch operation_setting.GetQuotaDisplayType() {
+	case operation_setting.QuotaDisplayTypeCNY:
+		return "CNY", operation_setting.GetCurrencySymbol()
+	case operation_setting.QuotaDisplayTypeCustom:
+		symbol = operation_setting.GetCurrencySymbol()
+		return symbol, symbol
+	default:
+		return "USD", "$"
+	}
+}
+
+// quotaToAmount 按额度展示设置的币种与汇率换算金额，保留 6 位小数
+func quotaToAmount(quota int) float64 {
+	rate := operation_setting.GetUsdToCurrencyRate(operation_setting.USDExchangeRate)
+	amount := float64(quota) / common.QuotaPerUnit * rate
+	return math.Round(amount*1e6) / 1e6
+}
+
+// GetSelfLogStatement 导出当前用户某月的消费账单，可按 token_name 过滤，format=csv 时以 CSV 下载
+func GetSelfLogStatement(c *gin.Context) {
+	renderLogStatement(c, c.GetInt("id"), "", c.Query("token_name"), false)
+}
+
+// GetAllLogStatement 管理员导出某月的消费账单，未指定 username 时按用户+模型汇总全部用户
+func GetAllLogStatement(c *gin.Context) {
+	username := c.Query("username")
+	renderLogStatement(c, 0, username, c.Query("token_name"), username == "")
+}
+
+func renderLogStatement(c *gin.Context, userId int, username string, tokenName string, byUser bool) {
+	start, end, err := parseStatementMonth(c.Query("month"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	items, err := model.GetConsumeStatement(userId, username, tokenName, start.Unix(), end.Unix(), byUser)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Username != items[j].Username {
+			return items[i].Username < items[j].Username
+		}
+		return items[i].Quota > items[j].Quota
+	})
+
+	total := statementTotal{}
+	for _, item := range items {
+		total.RequestCount += item.RequestCount
+		total.PromptTokens += item.PromptTokens
+		total.CompletionTokens += item.CompletionTokens
+		total.Quota += item.Quota
+	}
+	total.Amount = quotaToAmount(total.Quota)
+	month := start.Format("2006-01")
+	currency, currencySymbol := statementCurrency()
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=statement-%s.csv", month))
+		c.Status(http.StatusOK)
+		w := csv.NewWriter(c.Writer)
+		header := []string{"month", "model_name", "request_count", "prompt_tokens", "completion_tokens", "quota", "amount", "currency"}
+		if byUser {
+			header = append([]string{"username"}, header...)
+		}
+		_ = w.Write(header)
+		for _, item := range items {
+			row := []string{
+				month,
+				item.ModelName,
+				strconv.FormatInt(item.RequestCount, 10),
+				strconv.Itoa(item.PromptTokens),
+				strconv.Itoa(item.CompletionTokens),
+				strconv.Itoa(item.Quota),
+				strconv.FormatFloat(quotaToAmount(item.Quota), 'f', -1, 64),
+				currency,
+			}
+			if byUser {
+				row = append([]string{item.Username}, row...)
+			}
+			_ = w.Write(row)
+		}
+		w.Flush()
+		return
+	}
+
+	type statementRow struct {
+		*model.StatementItem
+		Amount float64 `json:"amount"`
+	}
+	rows := make([]statementRow, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, statementRow{StatementItem: item, Amount: quotaToAmount(item.Quota)})
+	}
+	common.ApiSuccess(c, gin.H{
+		"month":           month,
+		"currency":        currency,
+		"currency_symbol": currencySymbol,
+		"start_timestamp": start.Unix(),
+		"end_timestamp":   end.Unix(),
+		"items":           rows,
+		"total":           total,
+	})
+}
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/i18n"
@@ -120,6 +122,15 @@ func GetTokenUsage(c *gin.Context) {
 		expiredAt = 0
 	}
 
+	// 基于消费日志的统计，未开启消费日志时均为 0
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix()
+	usage, err := model.GetTokenUsageSummary(token.Id, todayStart, now.AddDate(0, 0, -7).Unix())
+	if err != nil {
+		// 统计失败不影响令牌额度信息的返回，用量字段按 0 返回
+		usage = model.TokenUsageSummary{}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    true,
 		"message": "ok",
@@ -133,10 +144,92 @@ func GetTokenUsage(c *gin.Context) {
 			"model_limits":         token.GetModelLimitsMap(),
 			"model_limits_enabled": token.ModelLimitsEnabled,
 			"expires_at":           expiredAt,
+			"request_count":        usage.RequestCount,
+			"today_used":           usage.TodayQuota,
+			"today_request_count":  usage.TodayRequestCount,
+			"last_7_days_used":     usage.WeekQuota,
 		},
 	})
 }
 
+const (
+	tokenForecastDefaultDays = 7
+	tokenForecastMaxDays     = 90
+)
+
+// GetTokenUsageForecast 根据最近 days 天的消费日志估算令牌的日均消耗、预计耗尽时间与消耗趋势
+func GetTokenUsageForecast(c *gin.Context) {
+	token, err := model.GetTokenByIds(c.GetInt("token_id"), c.GetInt("id"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	days, _ := strconv.Atoi(c.Query("days"))
+	if days <= 0 {
+		days = tokenForecastDefaultDays
+	}
+	if days > tokenForecastMaxDays {
+		days = tokenForecastMaxDays
+	}
+
+	now := time.Now()
+	windowStat, err := model.SumTokenUsage(token.Id, now.AddDate(0, 0, -days).Unix())
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	// 以窗口中点为界，比较前后两半的消耗判断趋势
+	recentStat, err := model.SumTokenUsage(token.Id, now.Add(-time.Duration(days)*12*time.Hour).Unix())
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	earlierQuota := windowStat.Quota - recentStat.Quota
+	trend := "flat"
+	if recentStat.Quota*5 > earlierQuota*6 {
+		trend = "up"
+	} else if recentStat.Quota*5 < earlierQuota*4 {
+		trend = "down"
+	}
+
+	// 超额扣费可能使剩余额度为负，按 0 处理
+	remainQuota := token.RemainQuota
+	if remainQuota < 0 {
+		remainQuota = 0
+	}
+	var expiredAt *int64
+	if token.ExpiredTime != -1 {
+		expiredAt = &token.ExpiredTime
+	}
+
+	avgDaily := float64(windowStat.Quota) / float64(days)
+	var remainingDays *float64
+	var exhaustAt *int64
+	if !token.UnlimitedQuota && avgDaily > 0 {
+		d := float64(remainQuota) / avgDaily
+		at := now.Add(time.Duration(d * float64(24*time.Hour))).Unix()
+		// 令牌先于额度耗尽而过期时，以过期时间为准
+		if expiredAt != nil && *expiredAt < at {
+			at = *expiredAt
+			d = math.Max(0, float64(at-now.Unix())/86400)
+		}
+		remainingDays = &d
+		exhaustAt = &at
+	}
+
+	common.ApiSuccess(c, gin.H{
+		"window_days":     days,
+		"window_used":     windowStat.Quota,
+		"avg_daily_used":  avgDaily,
+		"total_available": remainQuota,
+		"unlimited_quota": token.UnlimitedQuota,
+		"remaining_days":  remainingDays,
+		"exhaust_at":      exhaustAt,
+		"expired_at":      expiredAt,
+		"trend":           trend,
+	})
+}
+
 func AddToken(c *gin.Context) {
 	token := model.Token{}
 	err := c.ShouldBindJSON(&token)
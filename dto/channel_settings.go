@@ -34,6 +34,9 @@ type ChannelOtherSettings struct {
 	AllowSafetyIdentifier   bool          `json:"allow_safety_identifier,omitempty"`   // 是否允许 safety_identifier 透传（默认过滤以保护用户隐私）
 	AllowIncludeObfuscation bool          `json:"allow_include_obfuscation,omitempty"` // 是否允许 stream_options.include_obfuscation 透传（默认过滤以避免关闭流混淆保护）
 	AwsKeyType              AwsKeyType    `json:"aws_key_type,omitempty"`
+	ConnectTimeout          int           `json:"connect_timeout,omitempty"`         // 与上游建立连接（含 TLS 握手）的超时时间，单位秒，0 表示仅使用全局设置
+	ResponseHeaderTimeout   int           `json:"response_header_timeout,omitempty"` // 请求发出后等待上游响应头的超时时间，单位秒，0 表示仅使用全局设置
+	MaxDuration             int           `json:"max_duration,omitempty"`            // 单次上游请求（含流式读取）的最长时长，单位秒，0 表示不限制
 }
 
 func (s *ChannelOtherSettings) IsOpenRouterEnterprise() bool {
@@ -2,13 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
@@ -57,13 +61,6 @@ func main() {
 		common.SysLog("running in debug mode")
 	}
 
-	defer func() {
-		err := model.CloseDB()
-		if err != nil {
-			common.FatalLog("failed to close database: " + err.Error())
-		}
-	}()
-
 	if common.RedisEnabled {
 		// for compatibility with old versions
 		common.MemoryCacheEnabled = true
@@ -189,10 +186,44 @@ func main() {
 	// Log startup success message
 	common.LogStartupSuccess(startTime, port)
 
-	err = server.Run(":" + port)
-	if err != nil {
-		common.FatalLog("failed to start HTTP server: " + err.Error())
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: server.Handler(),
+	}
+	go func() {
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			common.FatalLog("failed to start HTTP server: " + err.Error())
+		}
+	}()
+
+	// 优雅退出：停止接收新请求，等待进行中的请求（含流式）完成计费后再关闭
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	common.SysLog(fmt.Sprintf("shutting down, waiting up to %d seconds for in-flight requests", common.ShutdownTimeout))
+	shutdownTimeout := time.Duration(common.ShutdownTimeout) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	drained := true
+	if err := srv.Shutdown(ctx); err != nil {
+		drained = false
+		common.SysError("graceful shutdown timed out: " + err.Error())
+	}
+	// 在同一截止时间内等待请求结束时异步发起的退款等计费任务完成，随后立即落盘批量更新
+	if !common.WaitBillingTasks(ctx) {
+		common.SysError("timed out waiting for in-flight billing tasks")
+	}
+	if common.BatchUpdateEnabled {
+		model.FlushBatchUpdate()
+	}
+	// 超时后仍有请求在处理，不关闭数据库，避免其计费写入失败；进程退出时连接随之释放
+	if drained {
+		if err := model.CloseDB(); err != nil {
+			common.SysError("failed to close database: " + err.Error())
+		}
 	}
+	common.SysLog("server exited")
 }
 
 func InjectUmamiAnalytics() {
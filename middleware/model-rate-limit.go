@@ -21,44 +21,36 @@ const (
 	ModelRequestRateLimitSuccessCountMark = "MRRLS"
 )
 
-// 检查Redis中的请求限制
-func checkRedisRateLimit(ctx context.Context, rdb *redis.Client, key string, maxCount int, duration int64) (bool, error) {
+// 检查Redis中的请求限制，同时返回本次请求成功后的剩余次数与重置秒数
+func checkRedisRateLimit(ctx context.Context, rdb *redis.Client, key string, maxCount int, duration int64) (allowed bool, remaining int, reset int64, err error) {
 	// 如果maxCount为0，表示不限制
 	if maxCount == 0 {
-		return true, nil
+		return true, 0, 0, nil
 	}
 
-	// 获取当前计数
-	length, err := rdb.LLen(ctx, key).Result()
+	// 获取请求记录
+	times, err := redisRequestTimes(ctx, rdb, key, maxCount)
 	if err != nil {
-		return false, err
+		return false, 0, 0, err
 	}
-
-	// 如果未达到限制，允许请求
-	if length < int64(maxCount) {
-		return true, nil
-	}
-
-	// 检查时间窗口
-	oldTimeStr, _ := rdb.LIndex(ctx, key, -1).Result()
-	oldTime, err := time.Parse(timeFormat, oldTimeStr)
+	nowTime, err := rateLimitNow()
 	if err != nil {
-		return false, err
+		return false, 0, 0, err
 	}
 
-	nowTimeStr := time.Now().Format(timeFormat)
-	nowTime, err := time.Parse(timeFormat, nowTimeStr)
-	if err != nil {
-		return false, err
-	}
 	// 如果在时间窗口内已达到限制，拒绝请求
-	subTime := nowTime.Sub(oldTime).Seconds()
-	if int64(subTime) < duration {
+	if len(times) >= maxCount && int64(nowTime.Sub(times[len(times)-1]).Seconds()) < duration {
 		rdb.Expire(ctx, key, time.Duration(setting.ModelRequestRateLimitDurationMinutes)*time.Minute)
-		return false, nil
+		remaining, reset = rateLimitStatus(times, nowTime, maxCount, duration)
+		return false, remaining, reset, nil
 	}
 
-	return true, nil
+	// 未达到限制，按本次请求成功计入后的状态返回
+	if len(times) >= maxCount {
+		times = times[:maxCount-1]
+	}
+	remaining, reset = rateLimitStatus(append([]time.Time{nowTime}, times...), nowTime, maxCount, duration)
+	return true, remaining, reset, nil
 }
 
 // 记录Redis请求
@@ -83,12 +75,15 @@ func redisRateLimitHandler(duration int64, totalMaxCount, successMaxCount int) g
 
 		// 1. 检查成功请求数限制
 		successKey := fmt.Sprintf("rateLimit:%s:%s", ModelRequestRateLimitSuccessCountMark, userId)
-		allowed, err := checkRedisRateLimit(ctx, rdb, successKey, successMaxCount, duration)
+		allowed, remaining, reset, err := checkRedisRateLimit(ctx, rdb, successKey, successMaxCount, duration)
 		if err != nil {
 			fmt.Println("检查成功请求数限制失败:", err.Error())
 			abortWithOpenAiMessage(c, http.StatusInternalServerError, "rate_limit_check_failed")
 			return
 		}
+		if successMaxCount > 0 {
+			setRateLimitHeaders(c, successMaxCount, remaining, reset)
+		}
 		if !allowed {
 			abortWithOpenAiMessage(c, http.StatusTooManyRequests, fmt.Sprintf("您已达到请求数限制：%d分钟内最多请求%d次", setting.ModelRequestRateLimitDurationMinutes, successMaxCount))
 			return
@@ -99,7 +94,8 @@ func redisRateLimitHandler(duration int64, totalMaxCount, successMaxCount int) g
 			totalKey := fmt.Sprintf("rateLimit:%s", userId)
 			// 初始化
 			tb := limiter.New(ctx, rdb)
-			allowed, err = tb.Allow(
+			var tokens int64
+			allowed, tokens, err = tb.AllowWithTokens(
 				ctx,
 				totalKey,
 				limiter.WithCapacity(int64(totalMaxCount)*duration),
@@ -113,8 +109,11 @@ func redisRateLimitHandler(duration int64, totalMaxCount, successMaxCount int) g
 				return
 			}
 
+			remaining, reset := tokenBucketStatus(tokens, totalMaxCount, duration)
+			setRateLimitHeaders(c, totalMaxCount, remaining, reset)
 			if !allowed {
 				abortWithOpenAiMessage(c, http.StatusTooManyRequests, fmt.Sprintf("您已达到总请求数限制：%d分钟内最多请求%d次，包括失败次数，请检查您的请求是否正确", setting.ModelRequestRateLimitDurationMinutes, totalMaxCount))
+				return
 			}
 		}
 
@@ -138,16 +137,24 @@ func memoryRateLimitHandler(duration int64, totalMaxCount, successMaxCount int)
 		successKey := ModelRequestRateLimitSuccessCountMark + userId
 
 		// 1. 检查总请求数限制（当totalMaxCount为0时跳过）
-		if totalMaxCount > 0 && !inMemoryRateLimiter.Request(totalKey, totalMaxCount, duration) {
-			c.Status(http.StatusTooManyRequests)
-			c.Abort()
-			return
+		if totalMaxCount > 0 {
+			allowed, remaining, reset := inMemoryRateLimiter.RequestWithStatus(totalKey, totalMaxCount, duration)
+			setRateLimitHeaders(c, totalMaxCount, remaining, reset)
+			if !allowed {
+				c.Status(http.StatusTooManyRequests)
+				c.Abort()
+				return
+			}
 		}
 
 		// 2. 检查成功请求数限制
 		// 使用一个临时key来检查限制，这样可以避免实际记录
 		checkKey := successKey + "_check"
-		if !inMemoryRateLimiter.Request(checkKey, successMaxCount, duration) {
+		allowed, remaining, reset := inMemoryRateLimiter.RequestWithStatus(checkKey, successMaxCount, duration)
+		if !allowed || successMaxCount > 0 {
+			setRateLimitHeaders(c, successMaxCount, remaining, reset)
+		}
+		if !allowed {
 			c.Status(http.StatusTooManyRequests)
 			c.Abort()
 			return
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 )
 
 var timeFormat = "2006-01-02T15:04:05.000Z"
@@ -18,55 +20,130 @@ var defNext = func(c *gin.Context) {
 	c.Next()
 }
 
+// setRateLimitHeaders 写入标准限流响应头，reset 为距离下一个请求名额释放的秒数。
+// 多个限流中间件串联时只保留剩余次数最少的一组（相同时保留重置更晚的一组），避免内层限流覆盖外层更严格的结果
+func setRateLimitHeaders(c *gin.Context, limit int, remaining int, reset int64) {
+	header := c.Writer.Header()
+	if existing := header.Get("X-RateLimit-Remaining"); existing != "" {
+		if n, err := strconv.Atoi(existing); err == nil {
+			if n < remaining {
+				return
+			}
+			if existingReset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); n == remaining && err == nil && existingReset >= reset {
+				return
+			}
+		}
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+}
+
+// redisRequestTimes 一次读取 Redis 中记录的请求时间列表（按 [new --> old] 排列，长度不超过 maxRequestNum），
+// 限流判断与响应头计算共用该结果，不额外增加 Redis 往返
+func redisRequestTimes(ctx context.Context, rdb *redis.Client, key string, maxRequestNum int) ([]time.Time, error) {
+	if maxRequestNum <= 0 {
+		return nil, fmt.Errorf("invalid rate limit max request num: %d", maxRequestNum)
+	}
+	timeStrs, err := rdb.LRange(ctx, key, 0, int64(maxRequestNum-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	times := make([]time.Time, 0, len(timeStrs))
+	for _, timeStr := range timeStrs {
+		t, err := time.Parse(timeFormat, timeStr)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// rateLimitStatus 根据请求时间列表（[new --> old]）计算窗口内剩余请求数与重置秒数
+func rateLimitStatus(times []time.Time, nowTime time.Time, maxRequestNum int, duration int64) (remaining int, reset int64) {
+	used := 0
+	for _, t := range times {
+		elapsed := int64(nowTime.Sub(t).Seconds())
+		if elapsed < duration {
+			used++
+			// 最后一个命中窗口的即为窗口内最早的请求
+			reset = duration - elapsed
+		}
+	}
+	remaining = maxRequestNum - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, reset
+}
+
+// tokenBucketStatus 根据令牌桶剩余令牌数计算剩余请求数与重置秒数，
+// 桶每次请求消耗 duration 个令牌、每秒补充 maxRequestNum 个，reset 为再多出一次可用请求所需的秒数
+func tokenBucketStatus(tokens int64, maxRequestNum int, duration int64) (remaining int, reset int64) {
+	if duration <= 0 || maxRequestNum <= 0 {
+		return maxRequestNum, 0
+	}
+	if tokens < 0 {
+		tokens = 0
+	}
+	remaining = int(tokens / duration)
+	if remaining >= maxRequestNum {
+		return maxRequestNum, 0
+	}
+	missing := duration*int64(remaining+1) - tokens
+	reset = (missing + int64(maxRequestNum) - 1) / int64(maxRequestNum)
+	return remaining, reset
+}
+
+// rateLimitNow 与请求记录使用相同格式解析当前时间，保证两者可比较
+// time.Since will return negative number!
+// See: https://stackoverflow.com/questions/50970900/why-is-time-since-returning-negative-durations-on-windows
+func rateLimitNow() (time.Time, error) {
+	return time.Parse(timeFormat, time.Now().Format(timeFormat))
+}
+
 func redisRateLimiter(c *gin.Context, maxRequestNum int, duration int64, mark string) {
 	ctx := context.Background()
 	rdb := common.RDB
 	key := "rateLimit:" + mark + c.ClientIP()
-	listLength, err := rdb.LLen(ctx, key).Result()
+	times, err := redisRequestTimes(ctx, rdb, key, maxRequestNum)
 	if err != nil {
 		fmt.Println(err.Error())
 		c.Status(http.StatusInternalServerError)
 		c.Abort()
 		return
 	}
-	if listLength < int64(maxRequestNum) {
-		rdb.LPush(ctx, key, time.Now().Format(timeFormat))
+	nowTime, err := rateLimitNow()
+	if err != nil {
+		fmt.Println(err)
+		c.Status(http.StatusInternalServerError)
+		c.Abort()
+		return
+	}
+	if len(times) >= maxRequestNum && int64(nowTime.Sub(times[len(times)-1]).Seconds()) < duration {
 		rdb.Expire(ctx, key, common.RateLimitKeyExpirationDuration)
-	} else {
-		oldTimeStr, _ := rdb.LIndex(ctx, key, -1).Result()
-		oldTime, err := time.Parse(timeFormat, oldTimeStr)
-		if err != nil {
-			fmt.Println(err)
-			c.Status(http.StatusInternalServerError)
-			c.Abort()
-			return
-		}
-		nowTimeStr := time.Now().Format(timeFormat)
-		nowTime, err := time.Parse(timeFormat, nowTimeStr)
-		if err != nil {
-			fmt.Println(err)
-			c.Status(http.StatusInternalServerError)
-			c.Abort()
-			return
-		}
-		// time.Since will return negative number!
-		// See: https://stackoverflow.com/questions/50970900/why-is-time-since-returning-negative-durations-on-windows
-		if int64(nowTime.Sub(oldTime).Seconds()) < duration {
-			rdb.Expire(ctx, key, common.RateLimitKeyExpirationDuration)
-			c.Status(http.StatusTooManyRequests)
-			c.Abort()
-			return
-		} else {
-			rdb.LPush(ctx, key, time.Now().Format(timeFormat))
-			rdb.LTrim(ctx, key, 0, int64(maxRequestNum-1))
-			rdb.Expire(ctx, key, common.RateLimitKeyExpirationDuration)
-		}
+		remaining, reset := rateLimitStatus(times, nowTime, maxRequestNum, duration)
+		setRateLimitHeaders(c, maxRequestNum, remaining, reset)
+		c.Status(http.StatusTooManyRequests)
+		c.Abort()
+		return
+	}
+	rdb.LPush(ctx, key, time.Now().Format(timeFormat))
+	if len(times) >= maxRequestNum {
+		rdb.LTrim(ctx, key, 0, int64(maxRequestNum-1))
+		times = times[:maxRequestNum-1]
 	}
+	rdb.Expire(ctx, key, common.RateLimitKeyExpirationDuration)
+	remaining, reset := rateLimitStatus(append([]time.Time{nowTime}, times...), nowTime, maxRequestNum, duration)
+	setRateLimitHeaders(c, maxRequestNum, remaining, reset)
 }
 
 func memoryRateLimiter(c *gin.Context, maxRequestNum int, duration int64, mark string) {
 	key := mark + c.ClientIP()
-	if !inMemoryRateLimiter.Request(key, maxRequestNum, duration) {
+	allowed, remaining, reset := inMemoryRateLimiter.RequestWithStatus(key, maxRequestNum, duration)
+	setRateLimitHeaders(c, maxRequestNum, remaining, reset)
+	if !allowed {
 		c.Status(http.StatusTooManyRequests)
 		c.Abort()
 		return
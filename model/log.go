@@ -4,15 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/pkg/cachex"
 	"github.com/QuantumNous/new-api/types"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/samber/hot"
 	"gorm.io/gorm"
 )
 
@@ -373,6 +377,37 @@ func GetUserLogs(userId int, logType int, startTimestamp int64, endTimestamp int
 	return logs, total, err
 }
 
+// GetTokenConsumeLogs 分页查询单个令牌自身的消费日志，供持有令牌的用户自助查询
+func GetTokenConsumeLogs(tokenId int, startTimestamp int64, endTimestamp int64, modelName string, startIdx int, num int) (logs []*Log, total int64, err error) {
+	tx := LOG_DB.Where("logs.token_id = ? and logs.type = ?", tokenId, LogTypeConsume)
+	if modelName != "" {
+		modelNamePattern, err := sanitizeLikePattern(modelName)
+		if err != nil {
+			return nil, 0, err
+		}
+		tx = tx.Where("logs.model_name LIKE ? ESCAPE '!'", modelNamePattern)
+	}
+	if startTimestamp != 0 {
+		tx = tx.Where("logs.created_at >= ?", startTimestamp)
+	}
+	if endTimestamp != 0 {
+		tx = tx.Where("logs.created_at <= ?", endTimestamp)
+	}
+	err = tx.Model(&Log{}).Limit(logSearchCountLimit).Count(&total).Error
+	if err != nil {
+		common.SysError("failed to count token logs: " + err.Error())
+		return nil, 0, errors.New("查询日志失败")
+	}
+	err = tx.Order("logs.id desc").Limit(num).Offset(startIdx).Find(&logs).Error
+	if err != nil {
+		common.SysError("failed to search token logs: " + err.Error())
+		return nil, 0, errors.New("查询日志失败")
+	}
+
+	formatUserLogs(logs, startIdx)
+	return logs, total, err
+}
+
 type Stat struct {
 	Quota int `json:"quota"`
 	Rpm   int `json:"rpm"`
@@ -456,6 +491,171 @@ func SumUsedToken(logType int, startTimestamp int64, endTimestamp int64, modelNa
 	return token
 }
 
+type TokenUsageStat struct {
+	Quota        int   `json:"quota"`
+	RequestCount int64 `json:"request_count"`
+}
+
+// netQuotaExpr 消费额度减去退款额度，退款按退款日志的记录时间计入
+var netQuotaExpr = fmt.Sprintf("coalesce(sum(case when type = %d then -quota else quota end),0)", LogTypeRefund)
+
+// consumeCountExpr 仅统计消费日志条数，退款日志不计为请求
+var consumeCountExpr = fmt.Sprintf("coalesce(sum(case when type = %d then 1 else 0 end),0)", LogTypeConsume)
+
+// SumTokenUsage 统计令牌自 startTimestamp 起的消费额度（已扣除退款）与请求次数，startTimestamp 为 0 时统计全部记录
+func SumTokenUsage(tokenId int, startTimestamp int64) (stat TokenUsageStat, err error) {
+	tx := LOG_DB.Table("logs").Select(netQuotaExpr+" quota, "+consumeCountExpr+" request_count").
+		Where("token_id = ? and type in ?", tokenId, []int{LogTypeConsume, LogTypeRefund})
+	if startTimestamp != 0 {
+		tx = tx.Where("created_at >= ?", startTimestamp)
+	}
+	if err = tx.Scan(&stat).Error; err != nil {
+		common.SysError("failed to query token usage stat: " + err.Error())
+		return stat, errors.New("查询统计数据失败")
+	}
+	return stat, nil
+}
+
+type TokenUsageSummary struct {
+	RequestCount      int64 `json:"request_count"`
+	TodayQuota        int   `json:"today_quota"`
+	TodayRequestCount int64 `json:"today_request_count"`
+	WeekQuota         int   `json:"week_quota"`
+}
+
+// GetTokenUsageSummary 统计令牌的总请求次数，以及自 todayStart、weekStart 起的消费额度（已扣除退款）。
+// 时间窗口内的统计按 created_at 限定范围单次查询；总请求次数需扫描全部日志，经缓存后返回，可能略有延迟
+func GetTokenUsageSummary(tokenId int, todayStart int64, weekStart int64) (summary TokenUsageSummary, err error) {
+	netQuotaSince := fmt.Sprintf("coalesce(sum(case when created_at >= ? then (case when type = %d then -quota else quota end) else 0 end),0)", LogTypeRefund)
+	consumeCountSince := fmt.Sprintf("coalesce(sum(case when created_at >= ? and type = %d then 1 else 0 end),0)", LogTypeConsume)
+	err = LOG_DB.Table("logs").
+		Select(netQuotaSince+" today_quota, "+consumeCountSince+" today_request_count, "+netQuotaSince+" week_quota",
+			todayStart, todayStart, weekStart).
+		Where("token_id = ? and type in ? and created_at >= ?", tokenId, []int{LogTypeConsume, LogTypeRefund}, min(todayStart, weekStart)).
+		Scan(&summary).Error
+	if err != nil {
+		common.SysError("failed to query token usage summary: " + err.Error())
+		return summary, errors.New("查询统计数据失败")
+	}
+	summary.RequestCount, err = getTokenRequestCount(tokenId)
+	if err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+const (
+	tokenRequestCountCacheNamespace = "new-api:token_request_count:v1"
+	tokenRequestCountCacheTTL       = 5 * time.Minute
+	tokenRequestCountCacheCapacity  = 10000
+)
+
+var (
+	tokenRequestCountCacheOnce sync.Once
+	tokenRequestCountCache     *cachex.HybridCache[int64]
+)
+
+func getTokenRequestCountCache() *cachex.HybridCache[int64] {
+	tokenRequestCountCacheOnce.Do(func() {
+		tokenRequestCountCache = cachex.NewHybridCache[int64](cachex.HybridCacheConfig[int64]{
+			Namespace: cachex.Namespace(tokenRequestCountCacheNamespace),
+			Redis:     common.RDB,
+			RedisEnabled: func() bool {
+				return common.RedisEnabled && common.RDB != nil
+			},
+			RedisCodec: cachex.JSONCodec[int64]{},
+			Memory: func() *hot.HotCache[string, int64] {
+				return hot.NewHotCache[string, int64](hot.LRU, tokenRequestCountCacheCapacity).
+					WithTTL(tokenRequestCountCacheTTL).
+					WithJanitor().
+					Build()
+			},
+		})
+	})
+	return tokenRequestCountCache
+}
+
+// getTokenRequestCount 返回令牌的历史消费请求总数，结果缓存 tokenRequestCountCacheTTL，避免轮询时反复全量扫描日志
+func getTokenRequestCount(tokenId int) (int64, error) {
+	cache := getTokenRequestCountCache()
+	key := strconv.Itoa(tokenId)
+	if count, found, err := cache.Get(key); err == nil && found {
+		return count, nil
+	}
+	var count int64
+	err := LOG_DB.Table("logs").Where("token_id = ? and type = ?", tokenId, LogTypeConsume).Count(&count).Error
+	if err != nil {
+		common.SysError("failed to count token requests: " + err.Error())
+		return 0, errors.New("查询统计数据失败")
+	}
+	if err := cache.SetWithTTL(key, count, tokenRequestCountCacheTTL); err != nil {
+		common.SysError("failed to cache token request count: " + err.Error())
+	}
+	return count, nil
+}
+
+type StatementItem struct {
+	Username         string `json:"username,omitempty"`
+	ModelName        string `json:"model_name"`
+	RequestCount     int64  `json:"request_count"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	Quota            int    `json:"quota"`
+}
+
+// GetConsumeStatement 汇总 [startTimestamp, endTimestamp) 内的消费日志，按模型分组，userId / username 为空时不作限制；
+// byUser 为 true 时按用户+模型分组，用于管理员导出全部用户账单。
+// 同期的退款日志会从额度中扣除（按退款发生时间计入当期）
+func GetConsumeStatement(userId int, username string, tokenName string, startTimestamp int64, endTimestamp int64, byUser bool) (items []*StatementItem, err error) {
+	columns := "model_name, " + consumeCountExpr + " request_count, coalesce(sum(prompt_tokens),0) prompt_tokens, " +
+		"coalesce(sum(completion_tokens),0) completion_tokens, " + netQuotaExpr + " quota"
+	groupBy := "model_name"
+	if byUser {
+		columns = "username, " + columns
+		groupBy = "username, model_name"
+	}
+	tx := LOG_DB.Table("logs").Select(columns).
+		Where("type in ? and created_at >= ? and created_at < ?", []int{LogTypeConsume, LogTypeRefund}, startTimestamp, endTimestamp)
+	if userId != 0 {
+		tx = tx.Where("user_id = ?", userId)
+	}
+	if username != "" {
+		tx = tx.Where("username = ?", username)
+	}
+	if tokenName != "" {
+		tx = tx.Where("token_name = ?", tokenName)
+	}
+	if err = tx.Group(groupBy).Scan(&items).Error; err != nil {
+		common.SysError("failed to query consume statement: " + err.Error())
+		return nil, errors.New("查询账单失败")
+	}
+	return items, nil
+}
+
+func GetLogById(id int) (*Log, error) {
+	var log Log
+	err := LOG_DB.Where("id = ?", id).First(&log).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// UpdateLogOther 以原 other 为条件更新日志的 other 字段，返回是否更新成功。
+// 用于退款等需要防止并发重复处理的场景。
+func UpdateLogOther(log *Log, other map[string]interface{}) (bool, error) {
+	newOther := common.MapToJsonStr(other)
+	result := LOG_DB.Model(&Log{}).Where("id = ? AND other = ?", log.Id, log.Other).Update("other", newOther)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	log.Other = newOther
+	return true, nil
+}
+
 func DeleteOldLog(ctx context.Context, targetTimestamp int64, limit int) (int64, error) {
 	var total int64 = 0
 
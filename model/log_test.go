@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertTokenLog(t *testing.T, tokenId int, logType int, quota int, createdAt int64) {
+	t.Helper()
+	require.NoError(t, LOG_DB.Create(&Log{UserId: 1, TokenId: tokenId, Type: logType, Quota: quota, CreatedAt: createdAt}).Error)
+}
+
+func TestGetTokenUsageSummary(t *testing.T) {
+	truncateTables(t)
+
+	const tokenId = 101
+	now := time.Now()
+	insertTokenLog(t, tokenId, LogTypeConsume, 500, now.AddDate(0, 0, -8).Unix())
+	insertTokenLog(t, tokenId, LogTypeConsume, 200, now.AddDate(0, 0, -3).Unix())
+	insertTokenLog(t, tokenId, LogTypeConsume, 1000, now.Unix())
+	insertTokenLog(t, tokenId, LogTypeRefund, 300, now.Unix())
+	insertTokenLog(t, tokenId, LogTypeTopup, 9999, now.Unix())
+	insertTokenLog(t, tokenId+1, LogTypeConsume, 9999, now.Unix())
+
+	summary, err := GetTokenUsageSummary(tokenId, now.Add(-time.Hour).Unix(), now.AddDate(0, 0, -7).Unix())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), summary.RequestCount)
+	assert.Equal(t, 1000-300, summary.TodayQuota)
+	assert.Equal(t, int64(1), summary.TodayRequestCount)
+	assert.Equal(t, 200+1000-300, summary.WeekQuota)
+}
+
+func TestGetTokenUsageSummary_RequestCountIsCached(t *testing.T) {
+	truncateTables(t)
+
+	const tokenId = 102
+	now := time.Now()
+	insertTokenLog(t, tokenId, LogTypeConsume, 100, now.Unix())
+
+	summary, err := GetTokenUsageSummary(tokenId, now.Add(-time.Hour).Unix(), now.AddDate(0, 0, -7).Unix())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), summary.RequestCount)
+
+	// 总请求次数在缓存有效期内不重新统计，时间窗口内的额度实时更新
+	insertTokenLog(t, tokenId, LogTypeConsume, 100, now.Unix())
+	summary, err = GetTokenUsageSummary(tokenId, now.Add(-time.Hour).Unix(), now.AddDate(0, 0, -7).Unix())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), summary.RequestCount)
+	assert.Equal(t, 200, summary.TodayQuota)
+}
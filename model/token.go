@@ -365,7 +365,7 @@ func IncreaseTokenQuota(tokenId int, key string, quota int) (err error) {
 		return errors.New("quota 不能为负数！")
 	}
 	if common.RedisEnabled {
-		gopool.Go(func() {
+		common.BillingGo(func() {
 			err := cacheIncrTokenQuota(key, int64(quota))
 			if err != nil {
 				common.SysLog("failed to increase token quota: " + err.Error())
@@ -395,7 +395,7 @@ func DecreaseTokenQuota(id int, key string, quota int) (err error) {
 		return errors.New("quota 不能为负数！")
 	}
 	if common.RedisEnabled {
-		gopool.Go(func() {
+		common.BillingGo(func() {
 			err := cacheDecrTokenQuota(key, int64(quota))
 			if err != nil {
 				common.SysLog("failed to decrease token quota: " + err.Error())
@@ -39,6 +39,12 @@ func InitBatchUpdater() {
 	})
 }
 
+// FlushBatchUpdate writes all pending batched updates to the database immediately.
+// It is called on shutdown so that quota changes accumulated since the last tick are not lost.
+func FlushBatchUpdate() {
+	batchUpdate()
+}
+
 func addNewRecord(type_ int, id int, value int) {
 	batchUpdateLocks[type_].Lock()
 	defer batchUpdateLocks[type_].Unlock()
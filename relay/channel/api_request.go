@@ -12,6 +12,7 @@ import (
 	"time"
 
 	common2 "github.com/QuantumNous/new-api/common"
+	constant2 "github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/logger"
 	"github.com/QuantumNous/new-api/relay/common"
 	"github.com/QuantumNous/new-api/relay/constant"
@@ -150,6 +151,9 @@ func applyHeaderOverridePlaceholders(template string, c *gin.Context, apiKey str
 	if strings.Contains(template, "{api_key}") {
 		template = strings.ReplaceAll(template, "{api_key}", apiKey)
 	}
+	if strings.Contains(template, "{request_id}") && c != nil {
+		template = strings.ReplaceAll(template, "{request_id}", c.GetString(common2.RequestIdKey))
+	}
 	if strings.TrimSpace(template) == "" {
 		return "", false, nil
 	}
@@ -159,6 +163,8 @@ func applyHeaderOverridePlaceholders(template string, c *gin.Context, apiKey str
 // processHeaderOverride applies channel header overrides, with placeholder substitution.
 // Supported placeholders:
 //   - {api_key}: resolved to the channel API key
+//   - {request_id}: resolved to the gateway request id, for correlating logs with the upstream
+//     (it is also sent as X-Request-Id by default, see setGatewayRequestIdHeader)
 //   - {client_header:<name>}: resolved to the incoming request header value
 //
 // Header passthrough rules (keys only; values are ignored):
@@ -262,6 +268,27 @@ func processHeaderOverride(info *common.RelayInfo, c *gin.Context) (map[string]s
 	return headerOverride, nil
 }
 
+const gatewayRequestIdHeader = "X-Request-Id"
+
+// setGatewayRequestIdHeader forwards the gateway request id to the upstream as X-Request-Id by default.
+// Header override can still replace it, or suppress it with an empty value: {"X-Request-Id": ""}.
+func setGatewayRequestIdHeader(header http.Header, c *gin.Context, info *common.RelayInfo) {
+	if c == nil {
+		return
+	}
+	for k, v := range info.HeadersOverride {
+		if !strings.EqualFold(strings.TrimSpace(k), gatewayRequestIdHeader) {
+			continue
+		}
+		if str, ok := v.(string); ok && strings.TrimSpace(str) == "" {
+			return
+		}
+	}
+	if requestId := c.GetString(common2.RequestIdKey); requestId != "" {
+		header.Set(gatewayRequestIdHeader, requestId)
+	}
+}
+
 func applyHeaderOverrideToRequest(req *http.Request, headerOverride map[string]string) {
 	if req == nil {
 		return
@@ -292,6 +319,7 @@ func DoApiRequest(a Adaptor, c *gin.Context, info *common.RelayInfo, requestBody
 	if err != nil {
 		return nil, fmt.Errorf("setup request header failed: %w", err)
 	}
+	setGatewayRequestIdHeader(req.Header, c, info)
 	// 在 SetupRequestHeader 之后应用 Header Override，确保用户设置优先级最高
 	// 这样可以覆盖默认的 Authorization header 设置
 	headerOverride, err := processHeaderOverride(info, c)
@@ -325,6 +353,7 @@ func DoFormRequest(a Adaptor, c *gin.Context, info *common.RelayInfo, requestBod
 	if err != nil {
 		return nil, fmt.Errorf("setup request header failed: %w", err)
 	}
+	setGatewayRequestIdHeader(req.Header, c, info)
 	// 在 SetupRequestHeader 之后应用 Header Override，确保用户设置优先级最高
 	// 这样可以覆盖默认的 Authorization header 设置
 	headerOverride, err := processHeaderOverride(info, c)
@@ -349,6 +378,7 @@ func DoWssRequest(a Adaptor, c *gin.Context, info *common.RelayInfo, requestBody
 	if err != nil {
 		return nil, fmt.Errorf("setup request header failed: %w", err)
 	}
+	setGatewayRequestIdHeader(targetHeader, c, info)
 	// 在 SetupRequestHeader 之后应用 Header Override，确保用户设置优先级最高
 	// 这样可以覆盖默认的 Authorization header 设置
 	headerOverride, err := processHeaderOverride(info, c)
@@ -503,20 +533,53 @@ func doRequest(c *gin.Context, req *http.Request, info *common.RelayInfo) (*http
 		}
 	}
 
+	req, timeout := newChannelRequestTimeout(req, info.ChannelOtherSettings)
 	resp, err := client.Do(req)
 	if err != nil {
+		err = timeout.wrapError(err)
 		logger.LogError(c, "do request failed: "+err.Error())
 		return nil, types.NewError(err, types.ErrorCodeDoRequestFailed, types.ErrOptionWithHideErrMsg("upstream error: do request failed"))
 	}
 	if resp == nil {
+		timeout.stop()
 		return nil, errors.New("resp is nil")
 	}
+	timeout.gotResponse(resp)
+	recordUpstreamRequestId(c, resp)
 
 	_ = req.Body.Close()
 	_ = c.Request.Body.Close()
 	return resp, nil
 }
 
+// upstreamRequestIdHeaders lists the response headers upstreams commonly use to identify a request,
+// e.g. OpenAI returns x-request-id and Anthropic returns request-id.
+var upstreamRequestIdHeaders = []string{"X-Request-Id", "Request-Id"}
+
+const upstreamRequestIdResponseHeader = "X-Upstream-Request-Id"
+
+// ResetUpstreamRequestId clears the upstream request id recorded by a previous attempt,
+// so a retry on another channel never reports the id of a failed attempt.
+func ResetUpstreamRequestId(c *gin.Context) {
+	common2.SetContextKey(c, constant2.ContextKeyUpstreamRequestId, "")
+	c.Writer.Header().Del(upstreamRequestIdResponseHeader)
+}
+
+// recordUpstreamRequestId keeps the upstream's own request id so it can be stored in the log
+// and echoed to the client, allowing support investigations to correlate both sides.
+func recordUpstreamRequestId(c *gin.Context, resp *http.Response) {
+	ResetUpstreamRequestId(c)
+	for _, name := range upstreamRequestIdHeaders {
+		id := strings.TrimSpace(resp.Header.Get(name))
+		if id == "" {
+			continue
+		}
+		common2.SetContextKey(c, constant2.ContextKeyUpstreamRequestId, id)
+		c.Header(upstreamRequestIdResponseHeader, id)
+		return
+	}
+}
+
 func DoTaskApiRequest(a TaskAdaptor, c *gin.Context, info *common.RelayInfo, requestBody io.Reader) (*http.Response, error) {
 	fullRequestURL, err := a.BuildRequestURL(info)
 	if err != nil {
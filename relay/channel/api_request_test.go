@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
 	relaycommon "github.com/QuantumNous/new-api/relay/common"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
@@ -79,3 +81,89 @@ func TestProcessHeaderOverride_NonTestKeepsClientHeaderPlaceholder(t *testing.T)
 	require.NoError(t, err)
 	require.Equal(t, "trace-123", headers["X-Upstream-Trace"])
 }
+
+func TestProcessHeaderOverride_RequestIdPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx.Set(common.RequestIdKey, "20250101000000abcdefgh")
+
+	info := &relaycommon.RelayInfo{
+		ChannelMeta: &relaycommon.ChannelMeta{
+			HeadersOverride: map[string]any{
+				"X-Request-Id": "{request_id}",
+			},
+		},
+	}
+
+	headers, err := processHeaderOverride(info, ctx)
+	require.NoError(t, err)
+	require.Equal(t, "20250101000000abcdefgh", headers["X-Request-Id"])
+}
+
+func TestRecordUpstreamRequestId(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Request-Id", "req_upstream_1")
+	recordUpstreamRequestId(ctx, resp)
+
+	require.Equal(t, "req_upstream_1", common.GetContextKeyString(ctx, constant.ContextKeyUpstreamRequestId))
+	require.Equal(t, "req_upstream_1", recorder.Header().Get("X-Upstream-Request-Id"))
+}
+
+func TestRecordUpstreamRequestId_RetryDropsPreviousAttemptId(t *testing.T) {
+	t.Parallel()
+
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+
+	// attempt 1 on channel A returns an id
+	failed := &http.Response{Header: http.Header{}}
+	failed.Header.Set("X-Request-Id", "req_channel_a")
+	recordUpstreamRequestId(ctx, failed)
+
+	// retry on channel B returns no id
+	ResetUpstreamRequestId(ctx)
+	succeeded := &http.Response{Header: http.Header{}}
+	recordUpstreamRequestId(ctx, succeeded)
+
+	require.Empty(t, common.GetContextKeyString(ctx, constant.ContextKeyUpstreamRequestId))
+	require.Empty(t, recorder.Header().Get("X-Upstream-Request-Id"))
+}
+
+func TestSetGatewayRequestIdHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		override map[string]any
+		want     string
+	}{
+		{name: "sent by default", override: nil, want: "gw-req-1"},
+		{name: "suppressed by empty override", override: map[string]any{"x-request-id": ""}, want: ""},
+		{name: "kept when override has a value", override: map[string]any{"X-Request-Id": "custom"}, want: "gw-req-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Set(common.RequestIdKey, "gw-req-1")
+
+			info := &relaycommon.RelayInfo{
+				ChannelMeta: &relaycommon.ChannelMeta{HeadersOverride: tt.override},
+			}
+			header := http.Header{}
+			setGatewayRequestIdHeader(header, ctx, info)
+			require.Equal(t, tt.want, header.Get("X-Request-Id"))
+		})
+	}
+}
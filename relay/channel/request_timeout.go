@@ -0,0 +1,113 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/dto"
+)
+
+var (
+	errChannelConnectTimeout        = errors.New("upstream connect timeout")
+	errChannelResponseHeaderTimeout = errors.New("upstream response header timeout")
+	errChannelMaxDuration           = errors.New("upstream request exceeded max duration")
+)
+
+// channelRequestTimeout 按渠道设置限制单次上游请求的连接、响应头与总时长，
+// 超时后取消请求上下文，正在进行的连接、等待或流式读取随之中断
+type channelRequestTimeout struct {
+	ctx         context.Context
+	cancel      context.CancelCauseFunc
+	connect     *time.Timer
+	header      *time.Timer
+	maxDuration *time.Timer
+	stopOnce    sync.Once
+}
+
+// newChannelRequestTimeout 为请求附加渠道超时，未配置任何超时时返回 nil 且请求不变
+func newChannelRequestTimeout(req *http.Request, settings dto.ChannelOtherSettings) (*http.Request, *channelRequestTimeout) {
+	if settings.ConnectTimeout <= 0 && settings.ResponseHeaderTimeout <= 0 && settings.MaxDuration <= 0 {
+		return req, nil
+	}
+	ctx, cancel := context.WithCancelCause(req.Context())
+	t := &channelRequestTimeout{ctx: ctx, cancel: cancel}
+	if settings.ConnectTimeout > 0 {
+		t.connect = time.AfterFunc(time.Duration(settings.ConnectTimeout)*time.Second, func() {
+			cancel(errChannelConnectTimeout)
+		})
+		ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			GotConn: func(httptrace.GotConnInfo) {
+				t.connect.Stop()
+			},
+		})
+	}
+	if settings.ResponseHeaderTimeout > 0 {
+		t.header = time.AfterFunc(time.Duration(settings.ResponseHeaderTimeout)*time.Second, func() {
+			cancel(errChannelResponseHeaderTimeout)
+		})
+	}
+	if settings.MaxDuration > 0 {
+		t.maxDuration = time.AfterFunc(time.Duration(settings.MaxDuration)*time.Second, func() {
+			cancel(errChannelMaxDuration)
+		})
+	}
+	return req.WithContext(ctx), t
+}
+
+// gotResponse 在收到响应头后停止连接与响应头计时，并在响应体关闭时释放总时长计时
+func (t *channelRequestTimeout) gotResponse(resp *http.Response) {
+	if t == nil {
+		return
+	}
+	stopTimer(t.connect)
+	stopTimer(t.header)
+	resp.Body = &timeoutReleasingBody{ReadCloser: resp.Body, release: t.stop}
+}
+
+// wrapError 请求失败时停止计时，若由渠道超时导致则返回对应的超时原因
+func (t *channelRequestTimeout) wrapError(err error) error {
+	if t == nil {
+		return err
+	}
+	t.stop()
+	if cause := context.Cause(t.ctx); errors.Is(cause, errChannelConnectTimeout) ||
+		errors.Is(cause, errChannelResponseHeaderTimeout) || errors.Is(cause, errChannelMaxDuration) {
+		return fmt.Errorf("%w: %v", cause, err)
+	}
+	return err
+}
+
+func (t *channelRequestTimeout) stop() {
+	if t == nil {
+		return
+	}
+	t.stopOnce.Do(func() {
+		stopTimer(t.connect)
+		stopTimer(t.header)
+		stopTimer(t.maxDuration)
+		t.cancel(nil)
+	})
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+type timeoutReleasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *timeoutReleasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,74 @@
+package channel
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelRequestTimeout_NoSettingsLeavesRequestUnchanged(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	got, timeout := newChannelRequestTimeout(req, dto.ChannelOtherSettings{})
+	require.Same(t, req, got)
+	require.Nil(t, timeout)
+	require.NoError(t, timeout.wrapError(nil))
+}
+
+func TestChannelRequestTimeout_ResponseHeaderTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req, timeout := newChannelRequestTimeout(req, dto.ChannelOtherSettings{ResponseHeaderTimeout: 1})
+	_, err = http.DefaultClient.Do(req)
+	require.Error(t, err)
+	require.True(t, errors.Is(timeout.wrapError(err), errChannelResponseHeaderTimeout))
+}
+
+func TestChannelRequestTimeout_MaxDurationStopsStreamRead(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("data: {}\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req, timeout := newChannelRequestTimeout(req, dto.ChannelOtherSettings{ResponseHeaderTimeout: 5, MaxDuration: 1})
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	timeout.gotResponse(resp)
+	defer resp.Body.Close()
+
+	start := time.Now()
+	_, err = io.ReadAll(resp.Body)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
@@ -33,6 +33,29 @@ func checkRedirect(req *http.Request, via []*http.Request) error {
 	return nil
 }
 
+// newRelayDialer 返回带连接超时的拨号器，RELAY_CONNECT_TIMEOUT 为 0 时不限制
+func newRelayDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   time.Duration(common.RelayConnectTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+}
+
+// applyRelayTransportTimeouts 设置连接、TLS 握手与等待响应头的超时，
+// 避免上游建立连接后迟迟不返回响应头时请求（及其预扣额度）被无限期占用。
+// 响应头返回后的流式读取不受影响，由 STREAMING_TIMEOUT 控制
+func applyRelayTransportTimeouts(transport *http.Transport) {
+	if transport.DialContext == nil {
+		transport.DialContext = newRelayDialer().DialContext
+	}
+	if common.RelayConnectTimeout > 0 {
+		transport.TLSHandshakeTimeout = time.Duration(common.RelayConnectTimeout) * time.Second
+	}
+	if common.RelayResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(common.RelayResponseHeaderTimeout) * time.Second
+	}
+}
+
 func InitHttpClient() {
 	transport := &http.Transport{
 		MaxIdleConns:        common.RelayMaxIdleConns,
@@ -40,6 +63,7 @@ func InitHttpClient() {
 		ForceAttemptHTTP2:   true,
 		Proxy:               http.ProxyFromEnvironment, // Support HTTP_PROXY, HTTPS_PROXY, NO_PROXY env vars
 	}
+	applyRelayTransportTimeouts(transport)
 	if common.TLSInsecureSkipVerify {
 		transport.TLSClientConfig = common.InsecureTLSConfig
 	}
@@ -111,6 +135,7 @@ func NewProxyHttpClient(proxyURL string) (*http.Client, error) {
 			ForceAttemptHTTP2:   true,
 			Proxy:               http.ProxyURL(parsedURL),
 		}
+		applyRelayTransportTimeouts(transport)
 		if common.TLSInsecureSkipVerify {
 			transport.TLSClientConfig = common.InsecureTLSConfig
 		}
@@ -139,7 +164,7 @@ func NewProxyHttpClient(proxyURL string) (*http.Client, error) {
 
 		// 创建 SOCKS5 代理拨号器
 		// proxy.SOCKS5 使用 tcp 参数，所有 TCP 连接包括 DNS 查询都将通过代理进行。行为与 socks5h 相同
-		dialer, err := proxy.SOCKS5("tcp", parsedURL.Host, auth, proxy.Direct)
+		dialer, err := proxy.SOCKS5("tcp", parsedURL.Host, auth, newRelayDialer())
 		if err != nil {
 			return nil, err
 		}
@@ -152,6 +177,7 @@ func NewProxyHttpClient(proxyURL string) (*http.Client, error) {
 				return dialer.Dial(network, addr)
 			},
 		}
+		applyRelayTransportTimeouts(transport)
 		if common.TLSInsecureSkipVerify {
 			transport.TLSClientConfig = common.InsecureTLSConfig
 		}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+)
+
+// RefundConsumeLog 按消费日志退还额度（管理员处理争议请求）。
+// quota <= 0 时退还剩余全部可退额度；累计退款额度记录在原日志 other.refunded_quota 中，不会超过原消费额度。
+// 操作管理员记录在退款日志的 other.admin_info 中，仅管理员可见，用于审计。
+func RefundConsumeLog(ctx context.Context, logId int, quota int, reason string, adminId int, adminUsername string) (*model.Log, error) {
+	consumeLog, err := model.GetLogById(logId)
+	if err != nil {
+		return nil, errors.New("日志不存在")
+	}
+	if consumeLog.Type != model.LogTypeConsume {
+		return nil, errors.New("只能对消费日志进行退款")
+	}
+	other := map[string]interface{}{}
+	if consumeLog.Other != "" {
+		other, err = common.StrToMap(consumeLog.Other)
+		if err != nil {
+			return nil, fmt.Errorf("解析日志 other 失败: %w", err)
+		}
+		if other == nil {
+			other = map[string]interface{}{}
+		}
+	}
+	refunded := 0
+	if v, ok := other["refunded_quota"].(float64); ok {
+		refunded = int(v)
+	}
+	remaining := consumeLog.Quota - refunded
+	if remaining <= 0 {
+		return nil, errors.New("该日志已全额退款")
+	}
+	if quota <= 0 {
+		quota = remaining
+	}
+	if quota > remaining {
+		return nil, fmt.Errorf("退款额度超出可退额度 %d", remaining)
+	}
+
+	// 1. 先在原日志上登记退款额度，防止并发重复退款
+	other["refunded_quota"] = refunded + quota
+	ok, err := model.UpdateLogOther(consumeLog, other)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("日志已被修改，请重试")
+	}
+
+	// 2. 退还资金来源（钱包或订阅）
+	refundTo := BillingSourceWallet
+	if subscriptionId := refundableSubscriptionId(consumeLog, other); subscriptionId > 0 {
+		refundTo = BillingSourceSubscription
+		err = model.PostConsumeUserSubscriptionDelta(subscriptionId, -int64(quota))
+	} else {
+		err = model.IncreaseUserQuota(consumeLog.UserId, quota, false)
+	}
+	if err != nil {
+		other["refunded_quota"] = refunded
+		if _, rollbackErr := model.UpdateLogOther(consumeLog, other); rollbackErr != nil {
+			logger.LogError(ctx, fmt.Sprintf("回滚日志 %d 退款标记失败: %s", logId, rollbackErr.Error()))
+		}
+		return nil, fmt.Errorf("退还资金来源失败: %w", err)
+	}
+
+	// 3. 退还令牌额度
+	if consumeLog.TokenId > 0 {
+		if token, err := model.GetTokenById(consumeLog.TokenId); err == nil {
+			if err := model.IncreaseTokenQuota(token.Id, token.Key, quota); err != nil {
+				logger.LogWarn(ctx, fmt.Sprintf("退还令牌额度失败 (log=%d): %s", logId, err.Error()))
+			}
+		} else {
+			logger.LogWarn(ctx, fmt.Sprintf("获取令牌失败 (tokenId=%d, log=%d): %s", consumeLog.TokenId, logId, err.Error()))
+		}
+	}
+
+	// 4. 扣减渠道已用额度
+	if consumeLog.ChannelId > 0 {
+		model.UpdateChannelUsedQuota(consumeLog.ChannelId, -quota)
+	}
+
+	// 5. 记录退款日志
+	refundOther := map[string]interface{}{
+		"refund_log_id": consumeLog.Id,
+		"reason":        reason,
+		"refund_to":     refundTo,
+		"admin_info": map[string]interface{}{
+			"admin_id":       adminId,
+			"admin_username": adminUsername,
+		},
+	}
+	if consumeLog.RequestId != "" {
+		refundOther["request_id"] = consumeLog.RequestId
+	}
+	model.RecordTaskBillingLog(model.RecordTaskBillingLogParams{
+		UserId:    consumeLog.UserId,
+		LogType:   model.LogTypeRefund,
+		Content:   fmt.Sprintf("管理员退还消费日志 %d 额度 %s", consumeLog.Id, logger.LogQuota(quota)),
+		ChannelId: consumeLog.ChannelId,
+		ModelName: consumeLog.ModelName,
+		Quota:     quota,
+		TokenId:   consumeLog.TokenId,
+		Group:     consumeLog.Group,
+		Other:     refundOther,
+	})
+	return consumeLog, nil
+}
+
+// refundableSubscriptionId 返回可退回的订阅 ID。
+// 仅当原订阅仍有效且仍处于扣费时的同一额度周期内才退回订阅，否则返回 0（退回钱包），
+// 避免周期重置后冲减新周期用量或退回到已失效的订阅。
+func refundableSubscriptionId(consumeLog *model.Log, other map[string]interface{}) int {
+	if other["billing_source"] != BillingSourceSubscription {
+		return 0
+	}
+	v, ok := other["subscription_id"].(float64)
+	if !ok || int(v) <= 0 {
+		return 0
+	}
+	sub, err := model.GetUserSubscriptionById(int(v))
+	if err != nil || sub.UserId != consumeLog.UserId {
+		return 0
+	}
+	now := common.GetTimestamp()
+	if sub.Status != "active" || sub.EndTime <= now {
+		return 0
+	}
+	if consumeLog.CreatedAt < sub.LastResetTime {
+		return 0
+	}
+	if sub.NextResetTime > 0 && sub.NextResetTime <= now {
+		return 0
+	}
+	return sub.Id
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// Seed / read-back helpers
+// ---------------------------------------------------------------------------
+
+func seedConsumeLog(t *testing.T, userId, tokenId, channelId, quota int, other map[string]interface{}) int {
+	t.Helper()
+	log := &model.Log{
+		UserId:    userId,
+		Username:  "test_user",
+		CreatedAt: time.Now().Unix(),
+		Type:      model.LogTypeConsume,
+		ModelName: "test-model",
+		TokenName: "test_token",
+		Quota:     quota,
+		ChannelId: channelId,
+		TokenId:   tokenId,
+		Group:     "default",
+		Other:     common.MapToJsonStr(other),
+	}
+	require.NoError(t, model.LOG_DB.Create(log).Error)
+	return log.Id
+}
+
+func setChannelUsedQuota(t *testing.T, id int, usedQuota int64) {
+	t.Helper()
+	require.NoError(t, model.DB.Model(&model.Channel{}).Where("id = ?", id).Update("used_quota", usedQuota).Error)
+}
+
+func getChannelUsedQuota(t *testing.T, id int) int64 {
+	t.Helper()
+	var ch model.Channel
+	require.NoError(t, model.DB.Select("used_quota").Where("id = ?", id).First(&ch).Error)
+	return ch.UsedQuota
+}
+
+func getRefundedQuota(t *testing.T, logId int) int {
+	t.Helper()
+	log, err := model.GetLogById(logId)
+	require.NoError(t, err)
+	other, err := common.StrToMap(log.Other)
+	require.NoError(t, err)
+	v, _ := other["refunded_quota"].(float64)
+	return int(v)
+}
+
+// ===========================================================================
+// RefundConsumeLog tests
+// ===========================================================================
+
+func TestRefundConsumeLog_Wallet(t *testing.T) {
+	tests := []struct {
+		name         string
+		refundQuota  int
+		wantRefunded int
+	}{
+		{name: "full refund", refundQuota: 0, wantRefunded: 1000},
+		{name: "partial refund", refundQuota: 400, wantRefunded: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncate(t)
+			ctx := context.Background()
+
+			const userID, tokenID, channelID = 10, 10, 10
+			const initQuota, tokenRemain, consumed = 5000, 3000, 1000
+
+			seedUser(t, userID, initQuota)
+			seedToken(t, tokenID, userID, "sk-refund-key", tokenRemain)
+			seedChannel(t, channelID)
+			setChannelUsedQuota(t, channelID, consumed)
+			logID := seedConsumeLog(t, userID, tokenID, channelID, consumed, map[string]interface{}{
+				"billing_source": BillingSourceWallet,
+			})
+
+			_, err := RefundConsumeLog(ctx, logID, tt.refundQuota, "disputed", 1, "root")
+			require.NoError(t, err)
+
+			assert.Equal(t, initQuota+tt.wantRefunded, getUserQuota(t, userID))
+			assert.Equal(t, tokenRemain+tt.wantRefunded, getTokenRemainQuota(t, tokenID))
+			assert.Equal(t, -tt.wantRefunded, getTokenUsedQuota(t, tokenID))
+			assert.Equal(t, int64(consumed-tt.wantRefunded), getChannelUsedQuota(t, channelID))
+			assert.Equal(t, tt.wantRefunded, getRefundedQuota(t, logID))
+
+			log := getLastLog(t)
+			require.NotNil(t, log)
+			assert.Equal(t, model.LogTypeRefund, log.Type)
+			assert.Equal(t, tt.wantRefunded, log.Quota)
+			other, err := common.StrToMap(log.Other)
+			require.NoError(t, err)
+			assert.EqualValues(t, logID, other["refund_log_id"])
+			assert.Equal(t, BillingSourceWallet, other["refund_to"])
+			adminInfo, ok := other["admin_info"].(map[string]interface{})
+			require.True(t, ok)
+			assert.EqualValues(t, 1, adminInfo["admin_id"])
+			assert.Equal(t, "root", adminInfo["admin_username"])
+		})
+	}
+}
+
+func TestRefundConsumeLog_ExceedsRemaining(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, consumed = 11, 1000
+	seedUser(t, userID, 0)
+	logID := seedConsumeLog(t, userID, 0, 0, consumed, nil)
+
+	_, err := RefundConsumeLog(ctx, logID, 600, "first", 1, "root")
+	require.NoError(t, err)
+
+	// 剩余可退 400，再退 600 应被拒绝且不改变状态
+	_, err = RefundConsumeLog(ctx, logID, 600, "second", 1, "root")
+	require.Error(t, err)
+	assert.Equal(t, 600, getRefundedQuota(t, logID))
+	assert.Equal(t, 600, getUserQuota(t, userID))
+
+	_, err = RefundConsumeLog(ctx, logID, 0, "rest", 1, "root")
+	require.NoError(t, err)
+	assert.Equal(t, consumed, getRefundedQuota(t, logID))
+
+	_, err = RefundConsumeLog(ctx, logID, 0, "again", 1, "root")
+	require.Error(t, err)
+	assert.Equal(t, consumed, getUserQuota(t, userID))
+}
+
+func TestRefundConsumeLog_Subscription(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, subID, consumed = 12, 12, 2000
+	const subTotal, subUsed int64 = 100000, 50000
+
+	seedUser(t, userID, 0)
+	seedSubscription(t, subID, userID, subTotal, subUsed)
+	logID := seedConsumeLog(t, userID, 0, 0, consumed, map[string]interface{}{
+		"billing_source":  BillingSourceSubscription,
+		"subscription_id": subID,
+	})
+
+	_, err := RefundConsumeLog(ctx, logID, 0, "disputed", 1, "root")
+	require.NoError(t, err)
+
+	assert.Equal(t, subUsed-consumed, getSubscriptionUsed(t, subID))
+	assert.Equal(t, 0, getUserQuota(t, userID))
+}
+
+func TestRefundConsumeLog_StaleSubscriptionFallsBackToWallet(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify map[string]interface{}
+	}{
+		{name: "expired", modify: map[string]interface{}{"status": "expired"}},
+		{name: "period reset", modify: map[string]interface{}{"last_reset_time": time.Now().Add(time.Hour).Unix()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncate(t)
+			ctx := context.Background()
+
+			const userID, subID, consumed = 13, 13, 2000
+			const subTotal, subUsed int64 = 100000, 50000
+
+			seedUser(t, userID, 0)
+			seedSubscription(t, subID, userID, subTotal, subUsed)
+			require.NoError(t, model.DB.Model(&model.UserSubscription{}).Where("id = ?", subID).Updates(tt.modify).Error)
+			logID := seedConsumeLog(t, userID, 0, 0, consumed, map[string]interface{}{
+				"billing_source":  BillingSourceSubscription,
+				"subscription_id": subID,
+			})
+
+			_, err := RefundConsumeLog(ctx, logID, 0, "disputed", 1, "root")
+			require.NoError(t, err)
+
+			assert.Equal(t, subUsed, getSubscriptionUsed(t, subID))
+			assert.Equal(t, consumed, getUserQuota(t, userID))
+		})
+	}
+}
+
+func TestRefundConsumeLog_FundingFailureRollsBack(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, consumed = 14, 1000
+	seedUser(t, userID, 0)
+	logID := seedConsumeLog(t, userID, 0, 0, consumed, nil)
+	before := countLogs(t)
+
+	// 使钱包退款写库失败
+	require.NoError(t, model.DB.Exec("ALTER TABLE users RENAME TO users_bak").Error)
+	_, err := RefundConsumeLog(ctx, logID, 0, "disputed", 1, "root")
+	require.NoError(t, model.DB.Exec("ALTER TABLE users_bak RENAME TO users").Error)
+
+	require.Error(t, err)
+	assert.Equal(t, 0, getRefundedQuota(t, logID))
+	assert.Equal(t, before, countLogs(t))
+}
+
+func TestRefundConsumeLog_RejectsNonConsumeLog(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	log := &model.Log{UserId: 15, Type: model.LogTypeTopup, Quota: 1000, CreatedAt: time.Now().Unix()}
+	require.NoError(t, model.LOG_DB.Create(log).Error)
+
+	_, err := RefundConsumeLog(ctx, log.Id, 0, "disputed", 1, "root")
+	require.Error(t, err)
+}
+
+func TestRefundConsumeLog_NetsOutOfTokenUsage(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, tokenID, consumed = 16, 16, 1000
+	seedUser(t, userID, 0)
+	seedToken(t, tokenID, userID, "sk-net-key", 0)
+	logID := seedConsumeLog(t, userID, tokenID, 0, consumed, nil)
+
+	_, err := RefundConsumeLog(ctx, logID, 300, "disputed", 1, "root")
+	require.NoError(t, err)
+
+	stat, err := model.SumTokenUsage(tokenID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, consumed-300, stat.Quota)
+	assert.Equal(t, int64(1), stat.RequestCount)
+}
@@ -178,6 +178,10 @@ const EditChannelModal = (props) => {
     allow_include_obfuscation: false,
     allow_inference_geo: false,
     claude_beta_query: false,
+    // 渠道级上游超时（单位秒，0 表示使用全局设置）
+    connect_timeout: 0,
+    response_header_timeout: 0,
+    max_duration: 0,
   };
   const [batch, setBatch] = useState(false);
   const [multiToSingle, setMultiToSingle] = useState(false);
@@ -653,6 +657,11 @@ const EditChannelModal = (props) => {
           data.allow_inference_geo =
             parsedSettings.allow_inference_geo || false;
           data.claude_beta_query = parsedSettings.claude_beta_query || false;
+          // 读取渠道级上游超时设置
+          data.connect_timeout = parsedSettings.connect_timeout || 0;
+          data.response_header_timeout =
+            parsedSettings.response_header_timeout || 0;
+          data.max_duration = parsedSettings.max_duration || 0;
         } catch (error) {
           console.error('解析其他设置失败:', error);
           data.azure_responses_version = '';
@@ -666,6 +675,9 @@ const EditChannelModal = (props) => {
           data.allow_include_obfuscation = false;
           data.allow_inference_geo = false;
           data.claude_beta_query = false;
+          data.connect_timeout = 0;
+          data.response_header_timeout = 0;
+          data.max_duration = 0;
         }
       } else {
         // 兼容历史数据：老渠道没有 settings 时，默认按 json 展示
@@ -678,6 +690,9 @@ const EditChannelModal = (props) => {
         data.allow_include_obfuscation = false;
         data.allow_inference_geo = false;
         data.claude_beta_query = false;
+        data.connect_timeout = 0;
+        data.response_header_timeout = 0;
+        data.max_duration = 0;
       }
 
       if (
@@ -1498,6 +1513,10 @@ const EditChannelModal = (props) => {
     delete localInputs.allow_include_obfuscation;
     delete localInputs.allow_inference_geo;
     delete localInputs.claude_beta_query;
+    // 清理渠道级上游超时的临时字段（已通过 settings 保存）
+    delete localInputs.connect_timeout;
+    delete localInputs.response_header_timeout;
+    delete localInputs.max_duration;
 
     let res;
     localInputs.auto_ban = localInputs.auto_ban ? 1 : 0;
@@ -3262,6 +3281,9 @@ const EditChannelModal = (props) => {
                               <div>
                                 {t('渠道密钥')}: {'{api_key}'}
                               </div>
+                              <div>
+                                {t('请求 ID')}: {'{request_id}'}
+                              </div>
                             </div>
                           </div>
                         </div>
@@ -3508,6 +3530,54 @@ const EditChannelModal = (props) => {
                       extraText={t('用于配置网络代理，支持 socks5 协议')}
                     />
 
+                    <Row gutter={12}>
+                      <Col span={8}>
+                        <Form.InputNumber
+                          field='connect_timeout'
+                          label={t('连接超时（秒）')}
+                          min={0}
+                          onNumberChange={(value) =>
+                            handleChannelOtherSettingsChange(
+                              'connect_timeout',
+                              value || 0,
+                            )
+                          }
+                          extraText={t('留空或 0 表示使用全局设置')}
+                          style={{ width: '100%' }}
+                        />
+                      </Col>
+                      <Col span={8}>
+                        <Form.InputNumber
+                          field='response_header_timeout'
+                          label={t('响应头超时（秒）')}
+                          min={0}
+                          onNumberChange={(value) =>
+                            handleChannelOtherSettingsChange(
+                              'response_header_timeout',
+                              value || 0,
+                            )
+                          }
+                          extraText={t('留空或 0 表示使用全局设置')}
+                          style={{ width: '100%' }}
+                        />
+                      </Col>
+                      <Col span={8}>
+                        <Form.InputNumber
+                          field='max_duration'
+                          label={t('最长请求时长（秒）')}
+                          min={0}
+                          onNumberChange={(value) =>
+                            handleChannelOtherSettingsChange(
+                              'max_duration',
+                              value || 0,
+                            )
+                          }
+                          extraText={t('留空或 0 表示不限制，包含流式读取')}
+                          style={{ width: '100%' }}
+                        />
+                      </Col>
+                    </Row>
+
                     <Form.TextArea
                       field='system_prompt'
                       label={t('系统提示词')}